@@ -0,0 +1,180 @@
+package xray
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+
+    "github.com/xtls/xray-core/app/commander"
+    logcmd "github.com/xtls/xray-core/app/log/command"
+    handlercmd "github.com/xtls/xray-core/app/proxyman/command"
+    proxymanoutbound "github.com/xtls/xray-core/app/proxyman/outbound"
+    "github.com/xtls/xray-core/app/router"
+    routercmd "github.com/xtls/xray-core/app/router/command"
+    statscmd "github.com/xtls/xray-core/app/stats/command"
+    "github.com/xtls/xray-core/common/protocol"
+    "github.com/xtls/xray-core/common/serial"
+    "github.com/xtls/xray-core/core"
+    "github.com/xtls/xray-core/features/inbound"
+    "github.com/xtls/xray-core/features/outbound"
+    "github.com/xtls/xray-core/features/routing"
+    "github.com/xtls/xray-core/infra/conf"
+    "github.com/xtls/xray-core/proxy"
+)
+
+var activeCommander *commander.Commander
+
+// StartCommander builds an app/commander.Config listing HandlerService,
+// LoggerService, StatsService and RoutingService, instantiates it against
+// the running core.Instance via core.CreateObject (the same path core.New
+// uses to build every App from its Config, so it never reaches into each
+// command package's internal service types) and starts it. Commander
+// registers itself as an outbound handler tagged "api", so routing a
+// dokodemo-door inbound to that tag reaches it, letting the app mutate
+// inbounds/outbounds/routing without a stop/start cycle — which on mobile
+// means never tearing down the tun.
+func StartCommander(listen string) error {
+    if server == nil {
+        return fmt.Errorf("xray server is not running")
+    }
+
+    config := &commander.Config{
+        Tag:    "api",
+        Listen: listen,
+        Service: []*serial.TypedMessage{
+            serial.ToTypedMessage(&handlercmd.Config{}),
+            serial.ToTypedMessage(&logcmd.Config{}),
+            serial.ToTypedMessage(&statscmd.Config{}),
+            serial.ToTypedMessage(&routercmd.Config{}),
+        },
+    }
+
+    obj, err := core.CreateObject(server, config)
+    if err != nil {
+        return fmt.Errorf("failed to create commander: %v", err)
+    }
+
+    c, ok := obj.(*commander.Commander)
+    if !ok {
+        return fmt.Errorf("unexpected commander object type %T", obj)
+    }
+
+    if err := c.Start(); err != nil {
+        return fmt.Errorf("failed to start commander: %v", err)
+    }
+
+    activeCommander = c
+    return nil
+}
+
+// StopCommander stops the Commander started by StartCommander.
+func StopCommander() error {
+    if activeCommander == nil {
+        return nil
+    }
+    err := activeCommander.Close()
+    activeCommander = nil
+    return err
+}
+
+// AddInboundUser adds a user to the inbound identified by tag. userJSON is
+// decoded with encoding/json into a protocol.User ("level", "email",
+// "account"), where "account" is a serialized typed message ("type": the
+// full proto message name, "value": its bytes in base64). The per-protocol
+// client objects of a config's "clients" list are not accepted.
+func AddInboundUser(tag, userJSON string) error {
+    return server.RequireFeatures(func(im inbound.Manager) error {
+        handler, err := im.GetHandler(context.Background(), tag)
+        if err != nil {
+            return fmt.Errorf("inbound %s not found: %v", tag, err)
+        }
+
+        um, ok := handler.(proxy.UserManager)
+        if !ok {
+            return fmt.Errorf("inbound %s does not support user management", tag)
+        }
+
+        rawUser := new(protocol.User)
+        if err := json.Unmarshal([]byte(userJSON), rawUser); err != nil {
+            return fmt.Errorf("invalid user json: %v", err)
+        }
+
+        mUser, err := rawUser.ToMemoryUser()
+        if err != nil {
+            return fmt.Errorf("failed to build user: %v", err)
+        }
+
+        return um.AddUser(context.Background(), mUser)
+    })
+}
+
+// RemoveInboundUser removes the user identified by email from the inbound
+// identified by tag.
+func RemoveInboundUser(tag, email string) error {
+    return server.RequireFeatures(func(im inbound.Manager) error {
+        handler, err := im.GetHandler(context.Background(), tag)
+        if err != nil {
+            return fmt.Errorf("inbound %s not found: %v", tag, err)
+        }
+
+        um, ok := handler.(proxy.UserManager)
+        if !ok {
+            return fmt.Errorf("inbound %s does not support user management", tag)
+        }
+
+        return um.RemoveUser(context.Background(), email)
+    })
+}
+
+// AlterOutbound replaces the outbound identified by tag with the one
+// described by outboundJSON (same schema as an "outbounds" entry in the
+// Xray config), creating it if it did not already exist.
+func AlterOutbound(tag, outboundJSON string) error {
+    return server.RequireFeatures(func(om outbound.Manager) error {
+        rawConfig := new(conf.OutboundDetourConfig)
+        if err := json.Unmarshal([]byte(outboundJSON), rawConfig); err != nil {
+            return fmt.Errorf("invalid outbound json: %v", err)
+        }
+        rawConfig.Tag = tag
+
+        oc, err := rawConfig.Build()
+        if err != nil {
+            return fmt.Errorf("failed to build outbound config: %v", err)
+        }
+
+        handler, err := proxymanoutbound.New(context.Background(), oc)
+        if err != nil {
+            return fmt.Errorf("failed to create outbound: %v", err)
+        }
+
+        _ = om.RemoveHandler(context.Background(), tag)
+
+        return om.AddHandler(context.Background(), handler)
+    })
+}
+
+// UpdateRouting replaces the running routing rules with the ones described
+// by rulesJSON (same schema as the top-level "routing" config object),
+// without restarting the server.
+func UpdateRouting(rulesJSON string) error {
+    return server.RequireFeatures(func(r routing.Router) error {
+        rawConfig := new(conf.RouterConfig)
+        if err := json.Unmarshal([]byte(rulesJSON), rawConfig); err != nil {
+            return fmt.Errorf("invalid routing json: %v", err)
+        }
+
+        routerConfig, err := rawConfig.Build()
+        if err != nil {
+            return fmt.Errorf("failed to build routing config: %v", err)
+        }
+
+        reloadable, ok := r.(interface {
+            Reset(*router.Config) error
+        })
+        if !ok {
+            return fmt.Errorf("routing feature does not support hot reload")
+        }
+
+        return reloadable.Reset(routerConfig)
+    })
+}
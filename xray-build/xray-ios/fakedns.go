@@ -0,0 +1,103 @@
+package xray
+
+import (
+    "fmt"
+
+    "github.com/xtls/xray-core/app/dns/fakedns"
+    "github.com/xtls/xray-core/app/proxyman"
+    "github.com/xtls/xray-core/common/net"
+    "github.com/xtls/xray-core/common/serial"
+    "github.com/xtls/xray-core/core"
+)
+
+// SniffingOptions mirrors the "sniffing" block of an inbound config and is
+// merged into the ReceiverSettings of every inbound that does not already
+// declare its own sniffing settings before core.New, the same
+// way DefaultDispatcher.getLink consults sniffing settings to decide
+// destOverride and whether to route on the sniffed result alone.
+type SniffingOptions struct {
+    Enabled      bool     `json:"enabled"`
+    DestOverride []string `json:"destOverride"`
+    MetadataOnly bool     `json:"metadataOnly"`
+    RouteOnly    bool     `json:"routeOnly"`
+}
+
+// applySniffing merges sniffing into every inbound of config whose
+// ReceiverConfig doesn't already declare its own SniffingSettings.
+// Sniffing settings live in app/proxyman.ReceiverConfig, reached through
+// the inbound's ReceiverSettings typed message, not on the inbound itself.
+func applySniffing(config *core.Config, sniffing SniffingOptions) {
+    for _, ib := range config.Inbound {
+        recv := &proxyman.ReceiverConfig{}
+        if ib.ReceiverSettings != nil {
+            if existing, err := ib.ReceiverSettings.GetInstance(); err == nil {
+                if rc, ok := existing.(*proxyman.ReceiverConfig); ok {
+                    recv = rc
+                }
+            }
+        }
+
+        if recv.SniffingSettings != nil {
+            continue
+        }
+
+        recv.SniffingSettings = &proxyman.SniffingConfig{
+            Enabled:             sniffing.Enabled,
+            DestinationOverride: sniffing.DestOverride,
+            MetadataOnly:        sniffing.MetadataOnly,
+            RouteOnly:           sniffing.RouteOnly,
+        }
+        ib.ReceiverSettings = serial.ToTypedMessage(recv)
+    }
+}
+
+// pendingFakeDNS holds the pool requested by EnableFakeDNS until the next
+// StartXray/NewInstance call. It is added to the config before core.New,
+// the same as ensureStatsFeatures does for stats and policy, rather than
+// injected into an already-running core.
+var pendingFakeDNS *fakedns.FakeDnsPoolMulti
+
+// EnableFakeDNS arranges for a FakeDNS pool covering cidr4 and/or cidr6
+// (either may be empty, not both) to be added as an app of the next
+// instance started via StartXray or NewInstance, the same as a top-level
+// "fakedns" section of the config. No "fakedns" server entry is added to
+// the config's "dns" section. Resolved fake IPs can then be queried back
+// via ResolveFakeIP.
+func EnableFakeDNS(cidr4, cidr6 string) error {
+    if cidr4 == "" && cidr6 == "" {
+        return fmt.Errorf("at least one of cidr4/cidr6 is required")
+    }
+
+    pool := &fakedns.FakeDnsPoolMulti{}
+    if cidr4 != "" {
+        pool.Pools = append(pool.Pools, &fakedns.FakeDnsPool{IpPool: cidr4, LruSize: 65535})
+    }
+    if cidr6 != "" {
+        pool.Pools = append(pool.Pools, &fakedns.FakeDnsPool{IpPool: cidr6, LruSize: 65535})
+    }
+
+    pendingFakeDNS = pool
+    return nil
+}
+
+// ensureFakeDNS appends pendingFakeDNS, if any, to config.App as an app of
+// its own (not as a server of the DNS app) and clears it so it is only ever applied to the one instance it was set for.
+func ensureFakeDNS(config *core.Config) {
+    if pendingFakeDNS == nil {
+        return
+    }
+    config.App = append(config.App, serial.ToTypedMessage(pendingFakeDNS))
+    pendingFakeDNS = nil
+}
+
+// ResolveFakeIP returns the real destination hostname behind a previously
+// issued fake IP on the default instance, or "" if ip is not a known fake
+// IP. The Android/iOS layer uses this to render a human-readable
+// destination in a connection log.
+func ResolveFakeIP(ip string) string {
+    inst := defaultInstance()
+    if inst == nil || inst.fakeDNS == nil {
+        return ""
+    }
+    return inst.fakeDNS.GetDomainFromFakeDNS(net.ParseAddress(ip))
+}
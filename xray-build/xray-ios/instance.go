@@ -0,0 +1,250 @@
+package xray
+
+import (
+    "encoding/json"
+    "fmt"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/xtls/xray-core/app/dns/fakedns"
+    "github.com/xtls/xray-core/app/policy"
+    "github.com/xtls/xray-core/app/stats"
+    "github.com/xtls/xray-core/core"
+)
+
+// Instance wraps a single running xray-core along with the feature handles
+// derived from it and its own stats-delta baseline. Multiple Instances can
+// run concurrently, e.g. one core for LAN traffic and one for WAN, or two
+// profiles kept alive for A/B testing.
+type Instance struct {
+    core          *core.Instance
+    statsManager  stats.Manager
+    policyManager policy.Manager
+    fakeDNS       fakedns.Holder
+
+    statsMu        sync.Mutex
+    lastSnapshot   map[string]int64
+    lastSnapshotAt time.Time
+}
+
+var (
+    instancesMu sync.RWMutex
+    instances   = map[int64]*Instance{}
+    nextHandle  int64
+
+    // defaultHandle backs the legacy package-level functions (StartXray,
+    // GetStats, TestConnection, ...) kept for backward compatibility.
+    defaultHandle int64
+)
+
+// NewInstance loads configJSON into a new, unstarted Instance and returns a
+// handle for use with StartInstance, StopInstance and StatsForInstance.
+func NewInstance(configJSON string) (int64, error) {
+    inst, err := buildInstance(configJSON, nil)
+    if err != nil {
+        return 0, err
+    }
+    return registerInstance(inst), nil
+}
+
+// StartInstance starts the Instance identified by handle.
+func StartInstance(handle int64) error {
+    inst, err := lookupInstance(handle)
+    if err != nil {
+        return err
+    }
+    return inst.core.Start()
+}
+
+// StopInstance closes the Instance identified by handle and forgets it.
+func StopInstance(handle int64) error {
+    instancesMu.Lock()
+    inst, ok := instances[handle]
+    if ok {
+        delete(instances, handle)
+    }
+    instancesMu.Unlock()
+
+    if !ok {
+        return fmt.Errorf("no such instance: %d", handle)
+    }
+    return inst.core.Close()
+}
+
+// StatsForInstance returns the same JSON shape as GetStats, but for the
+// Instance identified by handle.
+func StatsForInstance(handle int64) string {
+    inst, err := lookupInstance(handle)
+    if err != nil {
+        return "{}"
+    }
+    return inst.getStats()
+}
+
+func buildInstance(configJSON string, sniffing *SniffingOptions) (*Instance, error) {
+    config, err := core.LoadConfig("json", []byte(configJSON))
+    if err != nil {
+        return nil, fmt.Errorf("failed to load config: %v", err)
+    }
+
+    ensureStatsFeatures(config)
+    ensureFakeDNS(config)
+    ensureLogLevel(config)
+
+    if sniffing != nil {
+        applySniffing(config, *sniffing)
+    }
+
+    c, err := core.New(config)
+    if err != nil {
+        return nil, fmt.Errorf("failed to create server: %v", err)
+    }
+
+    inst := &Instance{core: c}
+    inst.statsManager, _ = c.GetFeature(stats.ManagerType()).(stats.Manager)
+    inst.policyManager, _ = c.GetFeature(policy.ManagerType()).(policy.Manager)
+    inst.fakeDNS, _ = c.GetFeature(fakedns.HolderType()).(fakedns.Holder)
+    return inst, nil
+}
+
+func registerInstance(inst *Instance) int64 {
+    instancesMu.Lock()
+    defer instancesMu.Unlock()
+
+    nextHandle++
+    handle := nextHandle
+    instances[handle] = inst
+    return handle
+}
+
+func lookupInstance(handle int64) (*Instance, error) {
+    instancesMu.RLock()
+    defer instancesMu.RUnlock()
+
+    inst, ok := instances[handle]
+    if !ok {
+        return nil, fmt.Errorf("no such instance: %d", handle)
+    }
+    return inst, nil
+}
+
+// defaultInstance returns the Instance backing the legacy top-level
+// functions, or nil if none has been started via StartXray yet.
+func defaultInstance() *Instance {
+    instancesMu.RLock()
+    handle := defaultHandle
+    inst := instances[handle]
+    instancesMu.RUnlock()
+    return inst
+}
+
+func (inst *Instance) getStats() string {
+    inbound := map[string]*linkStats{}
+    outbound := map[string]*linkStats{}
+    var totalUplink, totalDownlink int64
+
+    inst.visitTrafficCounters(func(direction, tag, kind string, value int64) {
+        var bucket map[string]*linkStats
+        switch direction {
+        case "inbound":
+            bucket = inbound
+        case "outbound":
+            bucket = outbound
+        default:
+            return
+        }
+
+        entry := bucket[tag]
+        if entry == nil {
+            entry = &linkStats{}
+            bucket[tag] = entry
+        }
+
+        switch kind {
+        case "uplink":
+            entry.Uplink = value
+            totalUplink += value
+        case "downlink":
+            entry.Downlink = value
+            totalDownlink += value
+        }
+    })
+
+    result := map[string]interface{}{
+        "inbound":       inbound,
+        "outbound":      outbound,
+        "totalUplink":   totalUplink,
+        "totalDownlink": totalDownlink,
+    }
+
+    data, _ := json.Marshal(result)
+    return string(data)
+}
+
+func (inst *Instance) resetStats(tag string) {
+    if inst.statsManager == nil {
+        return
+    }
+
+    for _, direction := range [...]string{"inbound", "outbound"} {
+        for _, kind := range [...]string{"uplink", "downlink"} {
+            name := fmt.Sprintf("%s>>>%s>>>traffic>>>%s", direction, tag, kind)
+            if c := inst.statsManager.GetCounter(name); c != nil {
+                c.Set(0)
+            }
+        }
+    }
+}
+
+func (inst *Instance) getStatsDelta() string {
+    inst.statsMu.Lock()
+    defer inst.statsMu.Unlock()
+
+    now := time.Now()
+    current := map[string]int64{}
+    inst.visitTrafficCounters(func(direction, tag, kind string, value int64) {
+        current[fmt.Sprintf("%s>>>%s>>>traffic>>>%s", direction, tag, kind)] = value
+    })
+
+    elapsed := now.Sub(inst.lastSnapshotAt).Seconds()
+    result := map[string]map[string]int64{}
+    for name, value := range current {
+        delta := value
+        if prev, ok := inst.lastSnapshot[name]; ok {
+            delta = value - prev
+        }
+        var rate int64
+        if elapsed > 0 {
+            rate = int64(float64(delta) / elapsed)
+        }
+        result[name] = map[string]int64{
+            "bytes":       delta,
+            "bytesPerSec": rate,
+        }
+    }
+
+    inst.lastSnapshot = current
+    inst.lastSnapshotAt = now
+
+    data, _ := json.Marshal(result)
+    return string(data)
+}
+
+// visitTrafficCounters walks every registered
+// "direction>>>tag>>>traffic>>>kind" counter and invokes fn with its
+// current value.
+func (inst *Instance) visitTrafficCounters(fn func(direction, tag, kind string, value int64)) {
+    if inst.statsManager == nil {
+        return
+    }
+
+    inst.statsManager.VisitCounters(func(name string, c stats.Counter) bool {
+        parts := strings.Split(name, ">>>")
+        if len(parts) != 4 || parts[2] != "traffic" {
+            return true
+        }
+        fn(parts[0], parts[1], parts[3], c.Value())
+        return true
+    })
+}
@@ -0,0 +1,152 @@
+package xray
+
+import (
+    "encoding/json"
+    "fmt"
+    "sync"
+
+    logapp "github.com/xtls/xray-core/app/log"
+    "github.com/xtls/xray-core/common/log"
+    "github.com/xtls/xray-core/common/serial"
+    "github.com/xtls/xray-core/core"
+)
+
+// LogCallback receives every xray-core log line, including the
+// errors.LogInfo/LogError calls used throughout the codebase, as it is
+// emitted, so a host app can show a live panel instead of losing the line
+// to stderr.
+type LogCallback func(level int, module, message string)
+
+var (
+    logMu        sync.Mutex
+    logCallback  LogCallback
+    logRing      []logEntry
+    logRingLimit = 500
+)
+
+type logEntry struct {
+    Level   int    `json:"level"`
+    Module  string `json:"module"`
+    Message string `json:"message"`
+}
+
+// bridgeHandler implements common/log.Handler, forwarding every record to
+// the registered LogCallback and appending it to the ring buffer.
+type bridgeHandler struct{}
+
+func (bridgeHandler) Handle(msg log.Message) {
+    entry := logEntry{
+        Level:   int(severityOf(msg)),
+        Module:  "xray",
+        Message: msg.String(),
+    }
+
+    logMu.Lock()
+    logRing = append(logRing, entry)
+    if len(logRing) > logRingLimit {
+        logRing = logRing[len(logRing)-logRingLimit:]
+    }
+    cb := logCallback
+    logMu.Unlock()
+
+    if cb != nil {
+        cb(entry.Level, entry.Module, entry.Message)
+    }
+}
+
+// severityOf extracts the severity of msg. common/log messages carry
+// severity as a field (e.g. *log.GeneralMessage.Severity), not via a
+// method, so this asserts the concrete type rather than an interface;
+// anything we don't recognize is reported at Info so a bad cast can never
+// crash the bridge.
+func severityOf(msg log.Message) log.Severity {
+    if gm, ok := msg.(*log.GeneralMessage); ok {
+        return gm.Severity
+    }
+    return log.Severity_Info
+}
+
+// SetLogCallback registers cb to receive every xray-core log line. Pass nil
+// to stop forwarding while still buffering into GetRecentLogs.
+func SetLogCallback(cb LogCallback) {
+    logMu.Lock()
+    logCallback = cb
+    logMu.Unlock()
+
+    log.RegisterHandler(bridgeHandler{})
+}
+
+// pendingLogLevel holds the level requested via SetLogLevel until the next
+// StartXray/NewInstance call. xray-core's log severity is driven by the
+// "log" app's config, not a runtime setter, so it takes effect on the next
+// (re)start rather than immediately.
+var pendingLogLevel string
+
+// SetLogLevel arranges for the next instance started via StartXray or
+// NewInstance to log at severity level, one of "debug", "info", "warning",
+// "error" or "none".
+func SetLogLevel(level string) error {
+    if _, err := log.SeverityFromString(level); err != nil {
+        return fmt.Errorf("invalid log level %q: %v", level, err)
+    }
+    pendingLogLevel = level
+    return nil
+}
+
+// ensureLogLevel applies pendingLogLevel, if any, to config's log app and
+// clears it, the same config-time mechanism ensureStatsFeatures and
+// ensureFakeDNS use. If a log app is already present it is updated in
+// place rather than duplicated, mirroring ensureStatsFeatures' dedup
+// against existing stats/policy apps.
+func ensureLogLevel(config *core.Config) {
+    if pendingLogLevel == "" {
+        return
+    }
+
+    severity, err := log.SeverityFromString(pendingLogLevel)
+    if err != nil {
+        pendingLogLevel = ""
+        return
+    }
+
+    logType := serial.GetMessageType(&logapp.Config{})
+    for _, app := range config.App {
+        if app.Type != logType {
+            continue
+        }
+        existing, err := app.GetInstance()
+        if err != nil {
+            continue
+        }
+        logConfig, ok := existing.(*logapp.Config)
+        if !ok {
+            continue
+        }
+        logConfig.ErrorLogLevel = severity
+        *app = *serial.ToTypedMessage(logConfig)
+        pendingLogLevel = ""
+        return
+    }
+
+    config.App = append(config.App, serial.ToTypedMessage(&logapp.Config{
+        ErrorLogType:  logapp.LogType_Console,
+        ErrorLogLevel: severity,
+    }))
+    pendingLogLevel = ""
+}
+
+// GetRecentLogs returns up to the last n buffered log entries as a JSON
+// array, or every buffered entry when n <= 0, so a bug report can be
+// attached without needing file access.
+func GetRecentLogs(n int) string {
+    logMu.Lock()
+    defer logMu.Unlock()
+
+    start := 0
+    if n > 0 && n < len(logRing) {
+        start = len(logRing) - n
+    }
+
+    data, _ := json.Marshal(logRing[start:])
+    return string(data)
+}
@@ -0,0 +1,104 @@
+package xray
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "time"
+
+    "github.com/xtls/xray-core/common/buf"
+    "github.com/xtls/xray-core/common/net"
+    "github.com/xtls/xray-core/common/session"
+    "github.com/xtls/xray-core/features/outbound"
+    "github.com/xtls/xray-core/transport"
+    "github.com/xtls/xray-core/transport/pipe"
+)
+
+const defaultProbeDestination = "www.google.com:443"
+
+// probeResult is the JSON shape returned by TestConnection. tcpRttMs,
+// tlsRttMs and firstByteMs are always equal: Dispatch only hands us a pipe
+// and never reveals when the TCP connect or TLS/XTLS/Reality handshake
+// against the real destination individually complete, so there is no way
+// to decompose the round trip into those phases from outside the handler.
+// All three are reported as the same round trip to the first response
+// byte, kept as distinct fields for API/schema compatibility with a future
+// implementation that can measure them separately.
+type probeResult struct {
+    OutboundTag string `json:"outboundTag"`
+    TCPRttMs    int64  `json:"tcpRttMs"`
+    TLSRttMs    int64  `json:"tlsRttMs"`
+    FirstByteMs int64  `json:"firstByteMs"`
+    Error       string `json:"error,omitempty"`
+}
+
+// TestConnection dials destination (default defaultProbeDestination when
+// empty) through the outbound identified by tag using the live
+// outbound.Manager, measuring round-trip latency to the first response
+// byte over the real proxy path. This reflects chains like XTLS/Reality/
+// WS, unlike an ICMP ping.
+func TestConnection(tag, destination string) string {
+    result := probeResult{OutboundTag: tag}
+
+    if destination == "" {
+        destination = defaultProbeDestination
+    }
+
+    dest, err := net.ParseDestination("tcp:" + destination)
+    if err != nil {
+        result.Error = fmt.Sprintf("invalid destination: %v", err)
+        return marshalProbeResult(result)
+    }
+
+    err = server.RequireFeatures(func(om outbound.Manager) error {
+        handler := om.GetHandler(tag)
+        if handler == nil {
+            return fmt.Errorf("outbound %s not found", tag)
+        }
+
+        ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+        defer cancel()
+        ctx = session.ContextWithOutbound(ctx, &session.Outbound{Target: dest})
+
+        uplinkReader, uplinkWriter := pipe.New()
+        downlinkReader, downlinkWriter := pipe.New()
+        link := &transport.Link{Reader: uplinkReader, Writer: downlinkWriter}
+
+        start := time.Now()
+        go handler.Dispatch(ctx, link)
+
+        // Address.Domain() panics for an IP destination; String() renders
+        // either a domain or an IP safely for the Host header.
+        probeRequest := buf.FromBytes([]byte(fmt.Sprintf("HEAD / HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n\r\n", dest.Address.String())))
+        if err := uplinkWriter.WriteMultiBuffer(buf.MultiBuffer{probeRequest}); err != nil {
+            return fmt.Errorf("failed to write probe request: %v", err)
+        }
+
+        if _, err := downlinkReader.ReadMultiBuffer(); err != nil {
+            return fmt.Errorf("no response from %s via outbound %s: %v", destination, tag, err)
+        }
+        elapsed := time.Since(start).Milliseconds()
+
+        // Dispatch only hands us a pipe; it never reveals when the TCP
+        // connect or TLS/XTLS/Reality handshake against the real
+        // destination individually complete, so the only thing we can
+        // honestly report is the round trip to the first response byte,
+        // attributed to all three fields.
+        result.TCPRttMs = elapsed
+        result.TLSRttMs = elapsed
+        result.FirstByteMs = elapsed
+
+        return nil
+    })
+
+    if err != nil {
+        result.Error = err.Error()
+    }
+
+    return marshalProbeResult(result)
+}
+
+func marshalProbeResult(result probeResult) string {
+    data, _ := json.Marshal(result)
+    return string(data)
+}
@@ -3,42 +3,158 @@ package xray
 import (
     "encoding/json"
     "fmt"
+
+    "github.com/xtls/xray-core/app/policy"
+    "github.com/xtls/xray-core/app/stats"
+    "github.com/xtls/xray-core/common/serial"
     "github.com/xtls/xray-core/core"
     "github.com/xtls/xray-core/main/commands/base"
 )
 
-var server core.Server
+// server, statsManager and policyManager mirror the default Instance
+// (see instance.go). server backs the legacy functions in commander.go and
+// probe.go; GetStats, ResetStats and GetStatsDelta below go through
+// defaultInstance instead. New code that needs more than one concurrent
+// core should use NewInstance / StartInstance / StopInstance /
+// StatsForInstance instead.
+var (
+    server        *core.Instance
+    statsManager  stats.Manager
+    policyManager policy.Manager
+)
 
-// StartXray starts the Xray server with the given config
-func StartXray(configJSON string) error {
-    config, err := core.LoadConfig("json", []byte(configJSON))
-    if err != nil {
-        return fmt.Errorf("failed to load config: %v", err)
+// StartXray starts the Xray server with the given config, using it as the
+// default instance for the legacy top-level functions. sniffingJSON, if
+// non-empty, is a JSON-encoded SniffingOptions merged into every inbound
+// that doesn't already declare its own "sniffing" block.
+func StartXray(configJSON, sniffingJSON string) error {
+    var sniffing *SniffingOptions
+    if sniffingJSON != "" {
+        sniffing = &SniffingOptions{}
+        if err := json.Unmarshal([]byte(sniffingJSON), sniffing); err != nil {
+            return fmt.Errorf("failed to parse sniffing options: %v", err)
+        }
     }
-    
-    server, err = core.New(config)
+
+    inst, err := buildInstance(configJSON, sniffing)
     if err != nil {
-        return fmt.Errorf("failed to create server: %v", err)
+        return err
     }
-    
-    return server.Start()
+
+    handle := registerInstance(inst)
+
+    instancesMu.Lock()
+    defaultHandle = handle
+    instancesMu.Unlock()
+    syncDefaultInstance(inst)
+
+    return StartInstance(handle)
 }
 
-// StopXray stops the Xray server
+// StopXray stops the default Xray instance started by StartXray. It is a
+// no-op if no default instance is running, so callers can invoke it
+// idempotently (e.g. StartXray failing partway, or StopXray called twice).
 func StopXray() error {
-    if server != nil {
-        return server.Close()
+    instancesMu.Lock()
+    handle := defaultHandle
+    defaultHandle = 0
+    instancesMu.Unlock()
+
+    syncDefaultInstance(nil)
+
+    if handle == 0 {
+        return nil
+    }
+
+    if _, err := lookupInstance(handle); err != nil {
+        // Already stopped or never registered: nothing to do.
+        return nil
     }
-    return nil
+    return StopInstance(handle)
 }
 
-// GetStats returns connection statistics
+// syncDefaultInstance keeps the legacy server/statsManager/policyManager
+// vars pointed at the default Instance, since commander.go and probe.go
+// use server directly.
+func syncDefaultInstance(inst *Instance) {
+    if inst == nil {
+        server, statsManager, policyManager = nil, nil, nil
+        return
+    }
+    server = inst.core
+    statsManager = inst.statsManager
+    policyManager = inst.policyManager
+}
+
+// ensureStatsFeatures makes sure the loaded config enables the stats and
+// policy apps so per-inbound/outbound traffic counters get registered.
+// Mobile-generated configs rarely include these since they add no proxying
+// behavior on their own.
+func ensureStatsFeatures(config *core.Config) {
+    hasStats := false
+    hasPolicy := false
+    for _, app := range config.App {
+        switch app.Type {
+        case serial.GetMessageType(&stats.Config{}):
+            hasStats = true
+        case serial.GetMessageType(&policy.Config{}):
+            hasPolicy = true
+        }
+    }
+
+    if !hasStats {
+        config.App = append(config.App, serial.ToTypedMessage(&stats.Config{}))
+    }
+
+    if !hasPolicy {
+        config.App = append(config.App, serial.ToTypedMessage(&policy.Config{
+            System: &policy.SystemPolicy{
+                Stats: &policy.SystemPolicy_Stat{
+                    InboundUplink:    true,
+                    InboundDownlink:  true,
+                    OutboundUplink:   true,
+                    OutboundDownlink: true,
+                },
+            },
+        }))
+    }
+}
+
+// linkStats holds the uplink/downlink byte counters for a single
+// inbound/outbound tag, as tracked by xray-core's StatCounterConnection.
+type linkStats struct {
+    Uplink   int64 `json:"uplink"`
+    Downlink int64 `json:"downlink"`
+}
+
+// GetStats returns per-inbound/outbound traffic statistics for the default
+// instance as a JSON object of the form {"inbound": {tag: {uplink,
+// downlink}}, "outbound": {...}, "totalUplink": n, "totalDownlink": n}.
 func GetStats() string {
-    stats := map[string]interface{}{
-        "uplink": 0,
-        "downlink": 0,
+    inst := defaultInstance()
+    if inst == nil {
+        return "{}"
+    }
+    return inst.getStats()
+}
+
+// ResetStats zeroes the uplink/downlink counters for the given inbound or
+// outbound tag on the default instance, for both directions.
+func ResetStats(tag string) {
+    if inst := defaultInstance(); inst != nil {
+        inst.resetStats(tag)
+    }
+}
+
+// GetStatsDelta returns, for every known traffic counter on the default
+// instance, the byte count accumulated and the resulting bytes/sec rate
+// since the previous call to GetStatsDelta. A mobile UI can poll this to
+// render a live throughput graph without having to diff raw counters
+// itself.
+func GetStatsDelta() string {
+    inst := defaultInstance()
+    if inst == nil {
+        return "{}"
     }
-    
-    data, _ := json.Marshal(stats)
-    return string(data)
+    return inst.getStatsDelta()
 }
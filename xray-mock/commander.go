@@ -0,0 +1,33 @@
+package xray
+
+import "fmt"
+
+// StartCommander simulates starting the gRPC Commander service.
+func StartCommander(listen string) string {
+    return fmt.Sprintf("Mock commander listening on %s", listen)
+}
+
+// StopCommander simulates stopping the gRPC Commander service.
+func StopCommander() string {
+    return "Mock commander stopped"
+}
+
+// AddInboundUser simulates adding a user to an inbound.
+func AddInboundUser(tag, userJSON string) string {
+    return fmt.Sprintf("Mock: added user to inbound %s", tag)
+}
+
+// RemoveInboundUser simulates removing a user from an inbound.
+func RemoveInboundUser(tag, email string) string {
+    return fmt.Sprintf("Mock: removed user %s from inbound %s", email, tag)
+}
+
+// AlterOutbound simulates replacing an outbound handler.
+func AlterOutbound(tag, outboundJSON string) string {
+    return fmt.Sprintf("Mock: altered outbound %s", tag)
+}
+
+// UpdateRouting simulates a routing hot-reload.
+func UpdateRouting(rulesJSON string) string {
+    return "Mock: routing updated"
+}
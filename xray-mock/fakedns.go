@@ -0,0 +1,15 @@
+package xray
+
+var mockFakeIPs = map[string]string{
+    "198.18.0.1": "example.com",
+}
+
+// EnableFakeDNS simulates registering a FakeDNS pool.
+func EnableFakeDNS(cidr4, cidr6 string) string {
+    return "Mock: fakedns enabled"
+}
+
+// ResolveFakeIP returns a mock hostname for a known mock fake IP, or "".
+func ResolveFakeIP(ip string) string {
+    return mockFakeIPs[ip]
+}
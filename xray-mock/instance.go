@@ -0,0 +1,63 @@
+package xray
+
+import (
+    "fmt"
+    "sync"
+)
+
+var (
+    mockInstancesMu sync.Mutex
+    mockInstances   = map[int64]*MockServer{}
+    mockNextHandle  int64
+)
+
+// NewInstance creates a new mock instance and returns its handle.
+func NewInstance(configJSON string) (int64, error) {
+    mockInstancesMu.Lock()
+    defer mockInstancesMu.Unlock()
+
+    mockNextHandle++
+    handle := mockNextHandle
+    mockInstances[handle] = &MockServer{counters: newMockCounters(handle)}
+    return handle, nil
+}
+
+// StartInstance marks the mock instance identified by handle as running.
+func StartInstance(handle int64) error {
+    mockInstancesMu.Lock()
+    defer mockInstancesMu.Unlock()
+
+    inst, ok := mockInstances[handle]
+    if !ok {
+        return fmt.Errorf("no such instance: %d", handle)
+    }
+    inst.isRunning = true
+    return nil
+}
+
+// StopInstance stops and forgets the mock instance identified by handle.
+func StopInstance(handle int64) error {
+    mockInstancesMu.Lock()
+    defer mockInstancesMu.Unlock()
+
+    if _, ok := mockInstances[handle]; !ok {
+        return fmt.Errorf("no such instance: %d", handle)
+    }
+    delete(mockInstances, handle)
+    return nil
+}
+
+// StatsForInstance returns mock stats reflecting the given instance's own
+// counters and running state, or "{}" if handle does not identify a live
+// instance.
+func StatsForInstance(handle int64) string {
+    mockInstancesMu.Lock()
+    inst, ok := mockInstances[handle]
+    mockInstancesMu.Unlock()
+
+    if !ok {
+        return "{}"
+    }
+
+    return statsJSON(inst)
+}
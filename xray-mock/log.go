@@ -0,0 +1,29 @@
+package xray
+
+import "encoding/json"
+
+// LogCallback has the same signature as the real build's log callback.
+type LogCallback func(level int, module, message string)
+
+var mockLogCallback LogCallback
+
+// SetLogCallback stores cb. The mock build emits no log lines, so cb is
+// never called.
+func SetLogCallback(cb LogCallback) {
+    mockLogCallback = cb
+}
+
+// SetLogLevel is a no-op in the mock build.
+func SetLogLevel(level string) error {
+    return nil
+}
+
+// GetRecentLogs returns a single canned mock log entry.
+func GetRecentLogs(n int) string {
+    entries := []map[string]interface{}{
+        {"level": 2, "module": "xray", "message": "Mock server running"},
+    }
+
+    data, _ := json.Marshal(entries)
+    return string(data)
+}
@@ -3,7 +3,6 @@ package xray
 
 import (
     "encoding/json"
-    "fmt"
     "time"
 )
 
@@ -11,12 +10,33 @@ import (
 type MockServer struct {
     isRunning bool
     startTime time.Time
+    counters  map[string]*mockLinkStats
 }
 
-var server = &MockServer{}
+// mockLinkStats mirrors the real implementation's per-tag shape so the host
+// app can share one JSON model between the mock and real builds.
+type mockLinkStats struct {
+    Uplink   int64 `json:"uplink"`
+    Downlink int64 `json:"downlink"`
+}
+
+// newMockCounters seeds a distinct "proxy" counter per instance, scaled by
+// handle, so StatsForInstance can actually tell instances apart.
+func newMockCounters(handle int64) map[string]*mockLinkStats {
+    scale := handle
+    if scale < 1 {
+        scale = 1
+    }
+    return map[string]*mockLinkStats{
+        "proxy": {Uplink: 1024 * 1024 * 10 * scale, Downlink: 1024 * 1024 * 50 * scale},
+    }
+}
+
+var server = &MockServer{counters: newMockCounters(1)}
 
-// StartXray starts the mock Xray server
-func StartXray(configJSON string) string {
+// StartXray starts the mock Xray server. sniffingJSON is accepted so the
+// parameter list matches the real build, but otherwise ignored.
+func StartXray(configJSON, sniffingJSON string) string {
     server.isRunning = true
     server.startTime = time.Now()
     return "Mock server started successfully"
@@ -28,26 +48,68 @@ func StopXray() string {
     return "Mock server stopped"
 }
 
-// GetStats returns mock connection statistics
+// GetStats returns mock connection statistics, shaped like the real
+// implementation's per-inbound/outbound counters.
 func GetStats() string {
+    return statsJSON(server)
+}
+
+// statsJSON renders inst's counters in the same shape GetStats/
+// StatsForInstance return.
+func statsJSON(inst *MockServer) string {
+    var totalUplink, totalDownlink int64
+    for _, c := range inst.counters {
+        totalUplink += c.Uplink
+        totalDownlink += c.Downlink
+    }
+
     stats := map[string]interface{}{
-        "uplink": 1024 * 1024 * 10,  // 10 MB
-        "downlink": 1024 * 1024 * 50, // 50 MB
-        "isRunning": server.isRunning,
+        "inbound":       map[string]interface{}{},
+        "outbound":      inst.counters,
+        "totalUplink":   totalUplink,
+        "totalDownlink": totalDownlink,
+        "isRunning":     inst.isRunning,
     }
-    
+
     data, _ := json.Marshal(stats)
     return string(data)
 }
 
-// TestConnection simulates a connection test
-func TestConnection(server string) string {
+// ResetStats zeroes the default instance's mock counters for the given tag.
+func ResetStats(tag string) {
+    if c, ok := server.counters[tag]; ok {
+        c.Uplink = 0
+        c.Downlink = 0
+    }
+}
+
+// GetStatsDelta returns a fixed mock throughput rate, since the mock server
+// has no real traffic to diff.
+func GetStatsDelta() string {
     result := map[string]interface{}{
-        "server": server,
-        "ping": 45,
-        "status": "connected",
+        "outbound>>>proxy>>>traffic>>>uplink": map[string]int64{
+            "bytes":       1024 * 64,
+            "bytesPerSec": 1024 * 64,
+        },
+        "outbound>>>proxy>>>traffic>>>downlink": map[string]int64{
+            "bytes":       1024 * 256,
+            "bytesPerSec": 1024 * 256,
+        },
     }
-    
+
+    data, _ := json.Marshal(result)
+    return string(data)
+}
+
+// TestConnection simulates a real connection probe through outboundTag.
+func TestConnection(outboundTag, destination string) string {
+    result := map[string]interface{}{
+        "outboundTag": outboundTag,
+        "tcpRttMs":    32,
+        "tlsRttMs":    58,
+        "firstByteMs": 120,
+    }
+
     data, _ := json.Marshal(result)
     return string(data)
 }